@@ -0,0 +1,113 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+)
+
+var _ Block = &AbortBlock{}
+
+// AbortBlock is always the child of a ProposalBlock. Accepting it discards
+// the change proposed by the parent's ProposalTx.
+type AbortBlock struct {
+	CommonBlock `serialize:"true"`
+}
+
+// Timestamp returns the parent ProposalBlock's timestamp.
+func (b *AbortBlock) Timestamp() time.Time {
+	parent, err := b.vm.GetBlock(b.Parent())
+	if err != nil {
+		return time.Unix(0, 0)
+	}
+	return parent.Timestamp()
+}
+
+// Verify checks that [b]'s parent is an undecided ProposalBlock at the
+// expected height.
+func (b *AbortBlock) Verify() error {
+	if b == nil {
+		return errBlockNil
+	}
+
+	parent, err := b.vm.GetBlock(b.Parent())
+	if err != nil {
+		return errDatabaseGet
+	}
+	if _, ok := parent.(*ProposalBlock); !ok {
+		return errProposalAlreadyDecided
+	}
+	if _, ok := b.vm.pendingProposals[b.Parent()]; !ok {
+		return errProposalAlreadyDecided
+	}
+	if expectedHeight := parent.Height() + 1; expectedHeight != b.Hght {
+		return errProposalAlreadyDecided
+	}
+
+	b.vm.verifiedBlocks[b.id] = b
+	b.vm.log.Debug("abort block verified", "id", b.id, "height", b.Hght, "proposal", b.Parent())
+	return nil
+}
+
+// Accept discards the change staged by the parent ProposalBlock's tx: its
+// onAbortDB is committed (leaving chain state untouched by the proposal)
+// and its onAbort side effect runs.
+func (b *AbortBlock) Accept() error {
+	b.SetStatus(choices.Accepted)
+	blkID := b.ID()
+
+	proposal, ok := b.vm.pendingProposals[b.Parent()]
+	if !ok {
+		return errProposalAlreadyDecided
+	}
+
+	if err := proposal.onAbortDB.Commit(); err != nil {
+		return err
+	}
+	proposal.onAbort()
+
+	if err := b.vm.state.PutAcceptedBlock(b); err != nil {
+		return err
+	}
+	if err := b.vm.state.SetLastAccepted(blkID); err != nil {
+		return err
+	}
+
+	delete(b.vm.verifiedBlocks, b.ID())
+	delete(b.vm.pendingProposals, b.Parent())
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	b.vm.blockCache.Put(blkID, b)
+	b.vm.log.Trace("abort block accepted", "id", blkID, "height", b.Hght, "proposal", b.Parent())
+	return nil
+}
+
+// Reject discards [b]; the parent ProposalBlock remains the chain tip until
+// the engine tries a CommitBlock (or another AbortBlock) in its place.
+func (b *AbortBlock) Reject() error {
+	b.SetStatus(choices.Rejected)
+	if err := b.vm.state.PutBlock(b); err != nil {
+		return err
+	}
+	delete(b.vm.verifiedBlocks, b.ID())
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	b.vm.blockCache.Evict(b.ID())
+	b.vm.log.Trace("abort block rejected", "id", b.id, "height", b.Hght, "proposal", b.Parent())
+	return nil
+}
+
+func newAbortBlock(parentID ids.ID, height uint64) *AbortBlock {
+	return &AbortBlock{
+		CommonBlock: CommonBlock{
+			PrntID: parentID,
+			Hght:   height,
+		},
+	}
+}
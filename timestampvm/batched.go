@@ -0,0 +1,83 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"context"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/snow/consensus/snowman"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+)
+
+// BatchedParseBlock parses each of [blksBytes] into a block, mirroring
+// avalanchego's block.BatchedChainVM interface so that proposervm can
+// fetch a whole range of blocks from a peer without round-tripping this
+// VM once per block. Blocks already known to the VM (verified or cached
+// as accepted) are served without touching the codec; everything else is
+// decoded in a single pass over [blksBytes].
+func (vm *VM) BatchedParseBlock(_ context.Context, blksBytes [][]byte) ([]snowman.Block, error) {
+	blocks := make([]snowman.Block, len(blksBytes))
+	for i, bytes := range blksBytes {
+		blkID := hashing.ComputeHash256Array(bytes)
+
+		if blk, ok := vm.verifiedBlocks[blkID]; ok {
+			blocks[i] = blk
+			continue
+		}
+		if blkIntf, ok := vm.blockCache.Get(blkID); ok {
+			blocks[i] = blkIntf.(Block)
+			continue
+		}
+
+		blk, err := parseBlockBytes(bytes)
+		if err != nil {
+			return nil, err
+		}
+		blk.Initialize(bytes, choices.Processing, vm)
+		blocks[i] = blk
+	}
+	return blocks, nil
+}
+
+// GetAncestors walks the parent chain from [blkID], collecting each
+// block's raw bytes, until it has [maxBlocksNum] of them, their combined
+// size would exceed [maxBlocksSize], [maxBlocksRetrievalTime] has elapsed,
+// or it reaches a block with no further ancestors. It mirrors
+// avalanchego's block.BatchedChainVM interface, letting a peer bootstrap a
+// long timestampvm chain with one request instead of one per block.
+func (vm *VM) GetAncestors(
+	_ context.Context,
+	blkID ids.ID,
+	maxBlocksNum int,
+	maxBlocksSize int,
+	maxBlocksRetrievalTime time.Duration,
+) ([][]byte, error) {
+	startTime := time.Now()
+
+	ancestors := make([][]byte, 0, maxBlocksNum)
+	totalSize := 0
+	for len(ancestors) < maxBlocksNum {
+		blkIntf, err := vm.GetBlock(blkID)
+		if err != nil {
+			break
+		}
+		blk := blkIntf.(Block)
+		bytes := blk.Bytes()
+
+		if len(ancestors) > 0 && totalSize+len(bytes) > maxBlocksSize {
+			break
+		}
+		ancestors = append(ancestors, bytes)
+		totalSize += len(bytes)
+
+		if blk.Height() == 0 || time.Since(startTime) > maxBlocksRetrievalTime {
+			break
+		}
+		blkID = blk.Parent()
+	}
+	return ancestors, nil
+}
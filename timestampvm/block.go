@@ -17,27 +17,36 @@ import (
 var (
 	errTimestampTooEarly = errors.New("block's timestamp is earlier than its parent's timestamp")
 	errDatabaseGet       = errors.New("error while retrieving data from database")
-	errTimestampTooLate  = errors.New("block's timestamp is more than 1 hour ahead of local time")
+	errTimestampTooLate  = errors.New("block's timestamp is too far ahead of local time")
 	errBlockNil          = errors.New("block is nil")
 
-	_ Block = &TimeBlock{}
+	_ Block = &StandardBlock{}
 )
 
+// blockKind tags which concrete Block type a block's serialized bytes
+// decode to, so VM.ParseBlock and VM.GetBlock can dispatch without
+// guessing. It's always the first byte of a block's Bytes().
+type blockKind byte
+
+const (
+	blockKindStandard blockKind = iota
+	blockKindProposal
+	blockKindCommit
+	blockKindAbort
+)
+
+// Block is the interface common to every timestampvm block type:
+// StandardBlock, ProposalBlock, CommitBlock and AbortBlock.
 type Block interface {
 	snowman.Block
 	Initialize(bytes []byte, status choices.Status, vm *VM)
-	Data() [dataLen]byte
 }
 
-// Block is a block on the chain.
-// Each block contains:
-// 1) A piece of data (a string)
-// 2) A timestamp
-type TimeBlock struct {
-	PrntID ids.ID        `serialize:"true" json:"parentID"`  // parent's ID
-	Hght   uint64        `serialize:"true" json:"height"`    // This block's height. The genesis block is at height 0.
-	Tmstmp int64         `serialize:"true" json:"timestamp"` // Time this block was proposed at
-	Dt     [dataLen]byte `serialize:"true" json:"data"`      // Arbitrary data
+// CommonBlock holds the fields and behavior shared by every timestampvm
+// block type.
+type CommonBlock struct {
+	PrntID ids.ID `serialize:"true" json:"parentID"` // parent's ID
+	Hght   uint64 `serialize:"true" json:"height"`   // This block's height. The genesis block is at height 0.
 
 	id     ids.ID
 	bytes  []byte
@@ -45,63 +54,115 @@ type TimeBlock struct {
 	vm     *VM
 }
 
-// Verify returns nil iff this block is valid.
-// To be valid, it must be that:
-// b.parent.Timestamp < b.Timestamp <= [local time] + 1 hour
-func (b *TimeBlock) Verify() error {
-	if b == nil {
-		return errBlockNil
-	}
+// Initialize sets [b.bytes] to [bytes], sets [b.id] to hash([b.bytes]) and
+// records [status] and [vm] for later use by the block's methods.
+func (b *CommonBlock) Initialize(bytes []byte, status choices.Status, vm *VM) {
+	b.vm = vm
+	b.bytes = bytes
+	b.id = hashing.ComputeHash256Array(bytes)
+	b.status = status
+}
 
-	// Get [b]'s parent
-	parentID := b.Parent()
-	parent, err := b.vm.GetBlock(parentID)
-	if err != nil {
-		return errDatabaseGet
-	}
+// ID returns the ID of this block
+func (b *CommonBlock) ID() ids.ID { return b.id }
+
+// Parent returns [b]'s parent's ID
+func (b *CommonBlock) Parent() ids.ID { return b.PrntID }
+
+// Height returns this block's height. The genesis block has height 0.
+func (b *CommonBlock) Height() uint64 { return b.Hght }
+
+// Status returns the status of this block
+func (b *CommonBlock) Status() choices.Status { return b.status }
+
+// Bytes returns the byte repr. of this block
+func (b *CommonBlock) Bytes() []byte { return b.bytes }
 
-	if expectedHeight := parent.Height() + 1; expectedHeight != b.Hght {
+// SetStatus sets the status of this block
+func (b *CommonBlock) SetStatus(status choices.Status) { b.status = status }
+
+// verifyCommon checks the invariants shared by every block type that
+// carries its own timestamp: that its height is exactly one more than
+// [parent]'s, and that its timestamp falls in
+// [parent.Timestamp(), now+maxClockSkew), compared in whole seconds.
+func verifyCommon(vm *VM, parent snowman.Block, hght uint64, timestamp time.Time) error {
+	if expectedHeight := parent.Height() + 1; expectedHeight != hght {
 		return fmt.Errorf(
 			"expected block to have height %d, but found %d",
 			expectedHeight,
-			b.Hght,
+			hght,
 		)
 	}
 
-	// Ensure [b]'s timestamp is after its parent's timestamp.
-	if b.Timestamp().Unix() < parent.Timestamp().Unix() {
+	if timestamp.Unix() < parent.Timestamp().Unix() {
 		return errTimestampTooEarly
 	}
 
-	// Ensure [b]'s timestamp is not more than an hour
-	// ahead of this node's time
-	if b.Timestamp().Unix() >= time.Now().Add(time.Hour).Unix() {
+	maxClockSkew, err := vm.state.GetMaxClockSkew()
+	if err != nil {
+		return err
+	}
+	if timestamp.Unix() >= time.Now().Add(maxClockSkew).Unix() {
 		return errTimestampTooLate
 	}
 
-	b.vm.verifiedBlocks[b.id] = b
-
 	return nil
 }
 
-// Initialize sets [b.bytes] to [bytes], sets [b.id] to hash([b.bytes])
-// Checks if [b]'s status is already stored in state. If so, [b] gets that status.
-// Otherwise [b]'s status is Unknown.
-func (b *TimeBlock) Initialize(bytes []byte, status choices.Status, vm *VM) {
-	b.vm = vm
-	b.bytes = bytes
-	b.id = hashing.ComputeHash256Array(b.bytes)
-	b.status = status
+// StandardBlock is a block on the chain that carries a batch of
+// user-submitted txs and a timestamp. It's the block type BuildBlock
+// produces whenever there's no pending on-chain governance proposal; see
+// ProposalBlock for the alternative.
+type StandardBlock struct {
+	CommonBlock `serialize:"true"`
+
+	Tmstmp int64     `serialize:"true" json:"timestamp"` // Time this block was proposed at
+	UsrTxs []*BaseTx `serialize:"true" json:"txs"`       // Txs contained in this block
+}
+
+// Verify returns nil iff this block is valid.
+// To be valid, it must be that:
+// b.parent.Timestamp <= b.Timestamp < [local time] + max clock skew
+func (b *StandardBlock) Verify() error {
+	if b == nil {
+		return errBlockNil
+	}
+
+	parent, err := b.vm.GetBlock(b.Parent())
+	if err != nil {
+		return errDatabaseGet
+	}
+
+	if err := verifyCommon(b.vm, parent, b.Hght, b.Timestamp()); err != nil {
+		return err
+	}
+
+	// Validate every tx in [b] against the VM's current state, which is the
+	// last accepted block's post-state; the post-state of an undecided
+	// parent is not used. A single invalid tx fails the whole block, and
+	// the mempool is left as it is.
+	for _, tx := range b.UsrTxs {
+		if err := tx.SemanticVerify(b.vm.state); err != nil {
+			return fmt.Errorf("tx %q failed semantic verification: %w", tx.ID(), err)
+		}
+	}
+
+	b.vm.verifiedBlocks[b.id] = b
+	b.vm.log.Debug("standard block verified", "id", b.id, "height", b.Hght, "txs", len(b.UsrTxs))
+	return nil
 }
 
 // Accept sets this block's status to Accepted and sets lastAccepted to this
-// block's ID and saves this info to b.vm.DB
-func (b *TimeBlock) Accept() error {
+// block's ID and saves this info to b.vm.DB. [b]'s txs are applied
+// atomically with the rest of this block: they're all persisted in the same
+// underlying batch and evicted from the mempool only once that batch
+// commits.
+func (b *StandardBlock) Accept() error {
 	b.SetStatus(choices.Accepted) // Change state of this block
 	blkID := b.ID()
 
 	// Persist data
-	if err := b.vm.state.PutBlock(b); err != nil {
+	if err := b.vm.state.PutAcceptedBlock(b); err != nil {
 		return err
 	}
 
@@ -110,50 +171,60 @@ func (b *TimeBlock) Accept() error {
 	}
 
 	delete(b.vm.verifiedBlocks, b.ID())
-	return b.vm.state.Commit()
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	b.vm.blockCache.Put(blkID, b)
+
+	b.vm.mempool.Remove(b.txIDs()...)
+	b.vm.log.Trace("block accepted", "id", blkID, "height", b.Hght, "ts", b.Tmstmp)
+	return nil
 }
 
 // Reject sets this block's status to Rejected and saves the status in state
 // Recall that b.vm.DB.Commit() must be called to persist to the DB
-func (b *TimeBlock) Reject() error {
+func (b *StandardBlock) Reject() error {
 	b.SetStatus(choices.Rejected)
 	if err := b.vm.state.PutBlock(b); err != nil {
 		return err
 	}
 	delete(b.vm.verifiedBlocks, b.ID())
-	return b.vm.state.Commit()
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	// A rejected block must never be served out of the accepted-block
+	// cache; evict it in case an earlier lookup cached it.
+	b.vm.blockCache.Evict(b.ID())
+
+	// [b]'s txs didn't make it on-chain; evict them so they aren't proposed
+	// again in a block descending from a different parent.
+	b.vm.mempool.Remove(b.txIDs()...)
+	b.vm.log.Trace("block rejected", "id", b.id, "height", b.Hght, "ts", b.Tmstmp)
+	return nil
 }
 
-// ID returns the ID of this block
-func (b *TimeBlock) ID() ids.ID { return b.id }
-
-// ParentID returns [b]'s parent's ID
-func (b *TimeBlock) Parent() ids.ID { return b.PrntID }
-
-// Height returns this block's height. The genesis block has height 0.
-func (b *TimeBlock) Height() uint64 { return b.Hght }
+// txIDs returns the IDs of the txs in [b].
+func (b *StandardBlock) txIDs() []ids.ID {
+	txIDs := make([]ids.ID, len(b.UsrTxs))
+	for i, tx := range b.UsrTxs {
+		txIDs[i] = tx.ID()
+	}
+	return txIDs
+}
 
 // Timestamp returns this block's time. The genesis block has time 0.
-func (b *TimeBlock) Timestamp() time.Time { return time.Unix(b.Tmstmp, 0) }
-
-// Status returns the status of this block
-func (b *TimeBlock) Status() choices.Status { return b.status }
+func (b *StandardBlock) Timestamp() time.Time { return time.Unix(b.Tmstmp, 0) }
 
-// Bytes returns the byte repr. of this block
-func (b *TimeBlock) Bytes() []byte { return b.bytes }
-
-// Data returns the data of this block
-func (b *TimeBlock) Data() [dataLen]byte { return b.Dt }
-
-// SetStatus sets the status of this block
-func (b *TimeBlock) SetStatus(status choices.Status) { b.status = status }
+// Txs returns the txs contained in this block
+func (b *StandardBlock) Txs() []*BaseTx { return b.UsrTxs }
 
-func newTimeBlock(parentID ids.ID, height uint64, data [dataLen]byte, timestamp time.Time) *TimeBlock {
-	// Create our new block
-	return &TimeBlock{
-		PrntID: parentID,
-		Hght:   height,
+func newStandardBlock(parentID ids.ID, height uint64, txs []*BaseTx, timestamp time.Time) *StandardBlock {
+	return &StandardBlock{
+		CommonBlock: CommonBlock{
+			PrntID: parentID,
+			Hght:   height,
+		},
 		Tmstmp: timestamp.Unix(),
-		Dt:     data,
+		UsrTxs: txs,
 	}
 }
@@ -0,0 +1,23 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/codec/linearcodec"
+)
+
+const codecVersion = 0
+
+// Codec does serialization and deserialization for the timestampvm's blocks
+// and transactions.
+var Codec codec.Manager
+
+func init() {
+	c := linearcodec.NewDefault()
+	Codec = codec.NewDefaultManager()
+	if err := Codec.RegisterCodec(codecVersion, c); err != nil {
+		panic(err)
+	}
+}
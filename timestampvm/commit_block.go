@@ -0,0 +1,114 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+)
+
+var _ Block = &CommitBlock{}
+
+// CommitBlock is always the child of a ProposalBlock. Accepting it applies
+// the change proposed by the parent's ProposalTx.
+type CommitBlock struct {
+	CommonBlock `serialize:"true"`
+}
+
+// Timestamp returns the parent ProposalBlock's timestamp: a
+// CommitBlock/AbortBlock is a same-instant decision on its parent, not a
+// new point in time.
+func (b *CommitBlock) Timestamp() time.Time {
+	parent, err := b.vm.GetBlock(b.Parent())
+	if err != nil {
+		return time.Unix(0, 0)
+	}
+	return parent.Timestamp()
+}
+
+// Verify checks that [b]'s parent is an undecided ProposalBlock at the
+// expected height.
+func (b *CommitBlock) Verify() error {
+	if b == nil {
+		return errBlockNil
+	}
+
+	parent, err := b.vm.GetBlock(b.Parent())
+	if err != nil {
+		return errDatabaseGet
+	}
+	if _, ok := parent.(*ProposalBlock); !ok {
+		return errProposalAlreadyDecided
+	}
+	if _, ok := b.vm.pendingProposals[b.Parent()]; !ok {
+		return errProposalAlreadyDecided
+	}
+	if expectedHeight := parent.Height() + 1; expectedHeight != b.Hght {
+		return errProposalAlreadyDecided
+	}
+
+	b.vm.verifiedBlocks[b.id] = b
+	b.vm.log.Debug("commit block verified", "id", b.id, "height", b.Hght, "proposal", b.Parent())
+	return nil
+}
+
+// Accept applies the change staged by the parent ProposalBlock's tx: its
+// onCommitDB is committed and its onCommit side effect runs.
+func (b *CommitBlock) Accept() error {
+	b.SetStatus(choices.Accepted)
+	blkID := b.ID()
+
+	proposal, ok := b.vm.pendingProposals[b.Parent()]
+	if !ok {
+		return errProposalAlreadyDecided
+	}
+
+	if err := proposal.onCommitDB.Commit(); err != nil {
+		return err
+	}
+	proposal.onCommit()
+
+	if err := b.vm.state.PutAcceptedBlock(b); err != nil {
+		return err
+	}
+	if err := b.vm.state.SetLastAccepted(blkID); err != nil {
+		return err
+	}
+
+	delete(b.vm.verifiedBlocks, b.ID())
+	delete(b.vm.pendingProposals, b.Parent())
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	b.vm.blockCache.Put(blkID, b)
+	b.vm.log.Trace("commit block accepted", "id", blkID, "height", b.Hght, "proposal", b.Parent())
+	return nil
+}
+
+// Reject discards [b]; the parent ProposalBlock remains the chain tip until
+// the engine tries an AbortBlock (or another CommitBlock) in its place.
+func (b *CommitBlock) Reject() error {
+	b.SetStatus(choices.Rejected)
+	if err := b.vm.state.PutBlock(b); err != nil {
+		return err
+	}
+	delete(b.vm.verifiedBlocks, b.ID())
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	b.vm.blockCache.Evict(b.ID())
+	b.vm.log.Trace("commit block rejected", "id", b.id, "height", b.Hght, "proposal", b.Parent())
+	return nil
+}
+
+func newCommitBlock(parentID ids.ID, height uint64) *CommitBlock {
+	return &CommitBlock{
+		CommonBlock: CommonBlock{
+			PrntID: parentID,
+			Hght:   height,
+		},
+	}
+}
@@ -0,0 +1,16 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+// defaultBlockCacheSize is used when the VM is given no config, or a config
+// that doesn't set BlockCacheSize.
+const defaultBlockCacheSize = 2048
+
+// Config is the timestampvm's VM configuration, supplied as the
+// configBytes argument to Initialize.
+type Config struct {
+	// BlockCacheSize is the number of accepted blocks kept in the in-memory
+	// LRU cache fronting the database.
+	BlockCacheSize int `json:"blockCacheSize"`
+}
@@ -0,0 +1,45 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database"
+)
+
+// defaultMaxClockSkew is the max clock skew tolerated by block Verify
+// before any SetMaxClockSkewTx has been accepted on-chain.
+const defaultMaxClockSkew = time.Hour
+
+var (
+	errNegativeClockSkew = errors.New("max clock skew can't be negative")
+
+	maxClockSkewKey = []byte("max clock skew")
+)
+
+// GetMaxClockSkew returns the max clock skew currently in effect, falling
+// back to defaultMaxClockSkew if no SetMaxClockSkewTx has ever been
+// accepted. Any database error other than ErrNotFound is propagated rather
+// than silently treated as "use the default": this is a consensus-relevant
+// parameter, not a cache.
+func (s *State) GetMaxClockSkew() (time.Duration, error) {
+	bytes, err := s.db.Get(maxClockSkewKey)
+	if err == database.ErrNotFound {
+		return defaultMaxClockSkew, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return time.Duration(binary.BigEndian.Uint64(bytes)), nil
+}
+
+// PutMaxClockSkew records [skew] as the max clock skew to enforce going
+// forward.
+func (s *State) PutMaxClockSkew(skew time.Duration) error {
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, uint64(skew))
+	return s.db.Put(maxClockSkewKey, bytes)
+}
@@ -0,0 +1,41 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+// CtxLogger adapts an avalanchego logging.Logger, such as a VM's
+// snow.Context.Log, to Logger: it's the default Logger a VM installs on
+// startup.
+type CtxLogger struct {
+	log logging.Logger
+}
+
+// NewCtxLogger wraps [l] as a Logger.
+func NewCtxLogger(l logging.Logger) *CtxLogger {
+	return &CtxLogger{log: l}
+}
+
+func (c *CtxLogger) Trace(msg string, kv ...interface{}) { c.log.Trace(format(msg, kv)) }
+func (c *CtxLogger) Debug(msg string, kv ...interface{}) { c.log.Debug(format(msg, kv)) }
+func (c *CtxLogger) Info(msg string, kv ...interface{})  { c.log.Info(format(msg, kv)) }
+func (c *CtxLogger) Warn(msg string, kv ...interface{})  { c.log.Warn(format(msg, kv)) }
+func (c *CtxLogger) Error(msg string, kv ...interface{}) { c.log.Error(format(msg, kv)) }
+
+// format renders [msg] followed by [kv]'s key/value pairs as "key=value",
+// space-separated, the way go-ethereum's logger does for backends that
+// don't understand structured fields natively.
+func format(msg string, kv []interface{}) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", kv[i], kv[i+1])
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package log provides the structured, leveled logging interface the
+// timestampvm emits at each block state transition. Following coreth's
+// switch away from verbose ctx.Log formatted strings, events are logged
+// as a message plus alternating key/value pairs, e.g.:
+//
+//	vm.log.Trace("block accepted", "id", b.id, "height", b.Hght)
+//
+// A VM holds its own Logger, defaulting to one that forwards into its
+// snow.Context (see NewCtxLogger), so operators can install a Logger
+// backed by zap, logrus, or anything else on a per-VM basis. A process
+// hosting multiple chains instantiates this VM once per chain, so the
+// Logger must never be process-wide global state.
+package log
+
+// Logger is the structured logging interface the timestampvm emits events
+// through. [ctx] is an alternating sequence of keys and values.
+type Logger interface {
+	Trace(msg string, ctx ...interface{})
+	Debug(msg string, ctx ...interface{})
+	Info(msg string, ctx ...interface{})
+	Warn(msg string, ctx ...interface{})
+	Error(msg string, ctx ...interface{})
+}
+
+// NoOp is a Logger that discards every call. It's a safe default for a VM
+// that hasn't installed a Logger yet.
+var NoOp Logger = noOpLogger{}
+
+type noOpLogger struct{}
+
+func (noOpLogger) Trace(string, ...interface{}) {}
+func (noOpLogger) Debug(string, ...interface{}) {}
+func (noOpLogger) Info(string, ...interface{})  {}
+func (noOpLogger) Warn(string, ...interface{})  {}
+func (noOpLogger) Error(string, ...interface{}) {}
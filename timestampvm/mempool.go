@@ -0,0 +1,124 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+// maxMempoolSize bounds how many pending txs the mempool will hold before
+// it starts rejecting new ones.
+const maxMempoolSize = 1024
+
+var (
+	errMempoolFull = errors.New("mempool is full")
+	errDuplicateTx = errors.New("tx already in mempool")
+
+	_ Mempool = &mempool{}
+)
+
+// Mempool holds the set of txs that have been gossiped to this node, or
+// issued locally, but that haven't yet been accepted into a block.
+//
+// Modeled on avalanchego's vms/avm/txs/mempool: a deduplicating, FIFO queue
+// that BuildBlock drains from and that Accept/Reject evict from.
+type Mempool interface {
+	// Add validates and adds [tx] to the mempool. Returns an error if [tx]
+	// is invalid or already present.
+	Add(tx Tx) error
+	// Get returns the tx with ID [txID], if it's in the mempool.
+	Get(txID ids.ID) (Tx, bool)
+	// Remove evicts [txIDs] from the mempool, e.g. because they were just
+	// accepted or rejected in a block.
+	Remove(txIDs ...ids.ID)
+	// Peek returns up to [maxTxs] of the oldest pending txs, for BuildBlock
+	// to propose. It does not remove them from the mempool.
+	Peek(maxTxs int) []Tx
+	// Len returns the number of txs currently pending.
+	Len() int
+}
+
+// mempool is a simple in-memory, deduplicating FIFO queue of pending txs.
+type mempool struct {
+	lock sync.RWMutex
+
+	txs    map[ids.ID]Tx
+	txHeap []Tx
+}
+
+// NewMempool creates a new, empty Mempool.
+func NewMempool() Mempool {
+	return &mempool{
+		txs: make(map[ids.ID]Tx),
+	}
+}
+
+func (m *mempool) Add(tx Tx) error {
+	if err := tx.SyntacticVerify(); err != nil {
+		return fmt.Errorf("tx %q failed syntactic verification: %w", tx.ID(), err)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	txID := tx.ID()
+	if _, ok := m.txs[txID]; ok {
+		return errDuplicateTx
+	}
+	if len(m.txs) >= maxMempoolSize {
+		return errMempoolFull
+	}
+
+	m.txs[txID] = tx
+	m.txHeap = append(m.txHeap, tx)
+	return nil
+}
+
+func (m *mempool) Get(txID ids.ID) (Tx, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	tx, ok := m.txs[txID]
+	return tx, ok
+}
+
+func (m *mempool) Remove(txIDs ...ids.ID) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, txID := range txIDs {
+		delete(m.txs, txID)
+	}
+
+	kept := m.txHeap[:0]
+	for _, tx := range m.txHeap {
+		if _, ok := m.txs[tx.ID()]; ok {
+			kept = append(kept, tx)
+		}
+	}
+	m.txHeap = kept
+}
+
+func (m *mempool) Peek(maxTxs int) []Tx {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if maxTxs > len(m.txHeap) {
+		maxTxs = len(m.txHeap)
+	}
+	txs := make([]Tx, maxTxs)
+	copy(txs, m.txHeap[:maxTxs])
+	return txs
+}
+
+func (m *mempool) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.txHeap)
+}
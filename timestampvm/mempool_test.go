@@ -0,0 +1,124 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func mustBaseTx(t *testing.T, seed uint64) *BaseTx {
+	t.Helper()
+	var data [dataLen]byte
+	binary.BigEndian.PutUint64(data[:8], seed)
+	tx, err := NewBaseTx(data)
+	if err != nil {
+		t.Fatalf("NewBaseTx(%d): %v", seed, err)
+	}
+	return tx
+}
+
+func TestMempoolAddGetLen(t *testing.T) {
+	m := NewMempool()
+	tx := mustBaseTx(t, 0)
+
+	if err := m.Add(tx); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if got := m.Len(); got != 1 {
+		t.Fatalf("Len() = %d, want 1", got)
+	}
+	got, ok := m.Get(tx.ID())
+	if !ok {
+		t.Fatalf("Get(%s) not found", tx.ID())
+	}
+	if got.ID() != tx.ID() {
+		t.Fatalf("Get(%s) returned tx %s", tx.ID(), got.ID())
+	}
+}
+
+func TestMempoolAddDuplicate(t *testing.T) {
+	m := NewMempool()
+	tx := mustBaseTx(t, 0)
+
+	if err := m.Add(tx); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := m.Add(tx); err != errDuplicateTx {
+		t.Fatalf("second Add returned %v, want errDuplicateTx", err)
+	}
+	if got := m.Len(); got != 1 {
+		t.Fatalf("Len() = %d, want 1", got)
+	}
+}
+
+func TestMempoolAddFull(t *testing.T) {
+	m := NewMempool()
+	for i := 0; i < maxMempoolSize; i++ {
+		if err := m.Add(mustBaseTx(t, uint64(i))); err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+	}
+
+	if err := m.Add(mustBaseTx(t, maxMempoolSize)); err != errMempoolFull {
+		t.Fatalf("Add past capacity returned %v, want errMempoolFull", err)
+	}
+	if got := m.Len(); got != maxMempoolSize {
+		t.Fatalf("Len() = %d, want %d", got, maxMempoolSize)
+	}
+}
+
+func TestMempoolPeekIsFIFOAndNonDestructive(t *testing.T) {
+	m := NewMempool()
+	txs := make([]*BaseTx, 3)
+	for i := range txs {
+		txs[i] = mustBaseTx(t, uint64(i))
+		if err := m.Add(txs[i]); err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+	}
+
+	peeked := m.Peek(2)
+	if len(peeked) != 2 {
+		t.Fatalf("Peek(2) returned %d txs, want 2", len(peeked))
+	}
+	for i, tx := range peeked {
+		if tx.ID() != txs[i].ID() {
+			t.Fatalf("Peek(2)[%d] = %s, want %s", i, tx.ID(), txs[i].ID())
+		}
+	}
+	if got := m.Len(); got != 3 {
+		t.Fatalf("Len() after Peek = %d, want 3 (Peek must not remove)", got)
+	}
+
+	all := m.Peek(10)
+	if len(all) != 3 {
+		t.Fatalf("Peek(10) returned %d txs, want 3", len(all))
+	}
+}
+
+func TestMempoolRemove(t *testing.T) {
+	m := NewMempool()
+	txs := make([]*BaseTx, 3)
+	for i := range txs {
+		txs[i] = mustBaseTx(t, uint64(i))
+		if err := m.Add(txs[i]); err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+	}
+
+	m.Remove(txs[1].ID())
+
+	if got := m.Len(); got != 2 {
+		t.Fatalf("Len() after Remove = %d, want 2", got)
+	}
+	if _, ok := m.Get(txs[1].ID()); ok {
+		t.Fatalf("Get(%s) still found after Remove", txs[1].ID())
+	}
+
+	remaining := m.Peek(10)
+	if len(remaining) != 2 || remaining[0].ID() != txs[0].ID() || remaining[1].ID() != txs[2].ID() {
+		t.Fatalf("Peek after Remove = %v, want [%s %s]", remaining, txs[0].ID(), txs[2].ID())
+	}
+}
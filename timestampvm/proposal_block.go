@@ -0,0 +1,122 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+)
+
+var (
+	errProposalAlreadyDecided = errors.New("proposal block already has a decided child")
+
+	_ Block = &ProposalBlock{}
+)
+
+// ProposalBlock carries a ProposalTx proposing an on-chain governance
+// change. Accepting it doesn't apply that change: the engine must then
+// accept exactly one of its two children, a CommitBlock or an AbortBlock,
+// which applies or discards the proposed change.
+type ProposalBlock struct {
+	CommonBlock `serialize:"true"`
+
+	Tmstmp  int64  `serialize:"true" json:"timestamp"`
+	TxBytes []byte `serialize:"true" json:"tx"`
+
+	tx ProposalTx
+}
+
+// Timestamp returns this block's time.
+func (b *ProposalBlock) Timestamp() time.Time { return time.Unix(b.Tmstmp, 0) }
+
+// Verify checks that [b] extends its parent at the expected height and
+// time, then stages [b.tx]'s proposed change so that whichever of
+// CommitBlock/AbortBlock is later accepted can apply or discard it.
+func (b *ProposalBlock) Verify() error {
+	if b == nil {
+		return errBlockNil
+	}
+
+	parent, err := b.vm.GetBlock(b.Parent())
+	if err != nil {
+		return errDatabaseGet
+	}
+
+	if err := verifyCommon(b.vm, parent, b.Hght, b.Timestamp()); err != nil {
+		return err
+	}
+
+	if err := b.tx.SyntacticVerify(); err != nil {
+		return err
+	}
+
+	onCommitDB, onAbortDB, onCommit, onAbort, err := b.tx.SemanticVerify(b.vm.state.db)
+	if err != nil {
+		return err
+	}
+	b.vm.pendingProposals[b.id] = &pendingProposal{
+		onCommitDB: onCommitDB,
+		onAbortDB:  onAbortDB,
+		onCommit:   onCommit,
+		onAbort:    onAbort,
+	}
+
+	b.vm.verifiedBlocks[b.id] = b
+	b.vm.log.Debug("proposal block verified", "id", b.id, "height", b.Hght, "tx", b.tx.ID())
+	return nil
+}
+
+// Accept marks [b] as accepted. The proposed change doesn't take effect
+// until the engine accepts whichever of [b]'s CommitBlock/AbortBlock
+// children it builds next; see CommitBlock.Accept/AbortBlock.Accept.
+func (b *ProposalBlock) Accept() error {
+	b.SetStatus(choices.Accepted)
+	blkID := b.ID()
+
+	if err := b.vm.state.PutAcceptedBlock(b); err != nil {
+		return err
+	}
+	if err := b.vm.state.SetLastAccepted(blkID); err != nil {
+		return err
+	}
+
+	delete(b.vm.verifiedBlocks, b.ID())
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	b.vm.blockCache.Put(blkID, b)
+	b.vm.log.Trace("proposal block accepted", "id", blkID, "height", b.Hght, "tx", b.tx.ID())
+	return nil
+}
+
+// Reject discards [b] and the proposed change it carries.
+func (b *ProposalBlock) Reject() error {
+	b.SetStatus(choices.Rejected)
+	if err := b.vm.state.PutBlock(b); err != nil {
+		return err
+	}
+	delete(b.vm.verifiedBlocks, b.ID())
+	delete(b.vm.pendingProposals, b.ID())
+	if err := b.vm.state.Commit(); err != nil {
+		return err
+	}
+	b.vm.blockCache.Evict(b.ID())
+	b.vm.log.Trace("proposal block rejected", "id", b.id, "height", b.Hght, "tx", b.tx.ID())
+	return nil
+}
+
+func newProposalBlock(parentID ids.ID, height uint64, tx ProposalTx, timestamp time.Time) *ProposalBlock {
+	return &ProposalBlock{
+		CommonBlock: CommonBlock{
+			PrntID: parentID,
+			Hght:   height,
+		},
+		Tmstmp:  timestamp.Unix(),
+		TxBytes: tx.Bytes(),
+		tx:      tx,
+	}
+}
@@ -0,0 +1,146 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/utils/cache"
+
+	"github.com/ava-labs/timestampvm/log"
+)
+
+// newTestVM builds a *VM with an accepted genesis block, bypassing
+// Initialize (which needs a real snow.Context and database manager).
+func newTestVM(t *testing.T) (*VM, *StandardBlock) {
+	t.Helper()
+
+	vm := &VM{
+		state:            NewState(versiondb.New(memdb.New())),
+		pendingProposals: make(map[ids.ID]*pendingProposal),
+		verifiedBlocks:   make(map[ids.ID]Block),
+		blockCache:       &cache.LRU{Size: defaultBlockCacheSize},
+		missingCache:     &cache.LRU{Size: defaultBlockCacheSize},
+		log:              log.NoOp,
+	}
+
+	genesis := newStandardBlock(ids.Empty, 0, nil, time.Unix(0, 0))
+	bytes, err := marshalBlock(blockKindStandard, genesis)
+	if err != nil {
+		t.Fatalf("marshalBlock(genesis): %v", err)
+	}
+	genesis.Initialize(bytes, choices.Accepted, vm)
+
+	if err := vm.state.PutAcceptedBlock(genesis); err != nil {
+		t.Fatalf("PutAcceptedBlock(genesis): %v", err)
+	}
+	if err := vm.state.SetLastAccepted(genesis.ID()); err != nil {
+		t.Fatalf("SetLastAccepted(genesis): %v", err)
+	}
+	if err := vm.state.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	vm.preferred = genesis.ID()
+	vm.blockCache.Put(genesis.ID(), genesis)
+
+	return vm, genesis
+}
+
+// proposeMaxClockSkew builds, verifies and accepts a ProposalBlock carrying
+// a SetMaxClockSkewTx extending [parent], and returns it.
+func proposeMaxClockSkew(t *testing.T, vm *VM, parent Block, newMaxClockSkew time.Duration) *ProposalBlock {
+	t.Helper()
+
+	tx, err := NewSetMaxClockSkewTx(newMaxClockSkew)
+	if err != nil {
+		t.Fatalf("NewSetMaxClockSkewTx: %v", err)
+	}
+
+	blk := newProposalBlock(parent.ID(), parent.Height()+1, tx, time.Now())
+	bytes, err := marshalBlock(blockKindProposal, blk)
+	if err != nil {
+		t.Fatalf("marshalBlock(proposal): %v", err)
+	}
+	blk.Initialize(bytes, choices.Processing, vm)
+
+	if err := blk.Verify(); err != nil {
+		t.Fatalf("ProposalBlock.Verify: %v", err)
+	}
+	if err := blk.Accept(); err != nil {
+		t.Fatalf("ProposalBlock.Accept: %v", err)
+	}
+	return blk
+}
+
+// TestProposalCommitAppliesMaxClockSkew runs a SetMaxClockSkewTx through
+// Propose -> Commit and checks that GetMaxClockSkew reflects the proposed
+// value afterward.
+func TestProposalCommitAppliesMaxClockSkew(t *testing.T) {
+	vm, genesis := newTestVM(t)
+	const newSkew = 2 * time.Hour
+
+	proposal := proposeMaxClockSkew(t, vm, genesis, newSkew)
+
+	commit := newCommitBlock(proposal.ID(), proposal.Height()+1)
+	bytes, err := marshalBlock(blockKindCommit, commit)
+	if err != nil {
+		t.Fatalf("marshalBlock(commit): %v", err)
+	}
+	commit.Initialize(bytes, choices.Processing, vm)
+
+	if err := commit.Verify(); err != nil {
+		t.Fatalf("CommitBlock.Verify: %v", err)
+	}
+	if err := commit.Accept(); err != nil {
+		t.Fatalf("CommitBlock.Accept: %v", err)
+	}
+
+	got, err := vm.state.GetMaxClockSkew()
+	if err != nil {
+		t.Fatalf("GetMaxClockSkew: %v", err)
+	}
+	if got != newSkew {
+		t.Fatalf("GetMaxClockSkew() = %s, want %s", got, newSkew)
+	}
+}
+
+// TestProposalAbortDiscardsMaxClockSkew runs a SetMaxClockSkewTx through
+// Propose -> Abort and checks that GetMaxClockSkew is left unchanged.
+func TestProposalAbortDiscardsMaxClockSkew(t *testing.T) {
+	vm, genesis := newTestVM(t)
+
+	before, err := vm.state.GetMaxClockSkew()
+	if err != nil {
+		t.Fatalf("GetMaxClockSkew (before): %v", err)
+	}
+
+	proposal := proposeMaxClockSkew(t, vm, genesis, 2*time.Hour)
+
+	abort := newAbortBlock(proposal.ID(), proposal.Height()+1)
+	bytes, err := marshalBlock(blockKindAbort, abort)
+	if err != nil {
+		t.Fatalf("marshalBlock(abort): %v", err)
+	}
+	abort.Initialize(bytes, choices.Processing, vm)
+
+	if err := abort.Verify(); err != nil {
+		t.Fatalf("AbortBlock.Verify: %v", err)
+	}
+	if err := abort.Accept(); err != nil {
+		t.Fatalf("AbortBlock.Accept: %v", err)
+	}
+
+	got, err := vm.state.GetMaxClockSkew()
+	if err != nil {
+		t.Fatalf("GetMaxClockSkew (after): %v", err)
+	}
+	if got != before {
+		t.Fatalf("GetMaxClockSkew() = %s after abort, want unchanged %s", got, before)
+	}
+}
@@ -0,0 +1,101 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"time"
+
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+)
+
+var _ ProposalTx = &SetMaxClockSkewTx{}
+
+// ProposalTx is a tx that proposes a change to on-chain governance
+// parameters rather than being applied unconditionally. It's carried by a
+// ProposalBlock and only takes effect once consensus accepts the
+// CommitBlock (or is undone, as a no-op, by an AbortBlock) that follows.
+type ProposalTx interface {
+	ID() ids.ID
+	Bytes() []byte
+	SyntacticVerify() error
+
+	// SemanticVerify checks that this tx may be applied on top of [db] and
+	// returns two divergent versions of [db]: [onCommitDB] has this tx's
+	// changes applied, [onAbortDB] doesn't. Exactly one of them is
+	// committed, depending on whether consensus accepts a CommitBlock or an
+	// AbortBlock following this tx's ProposalBlock. [onCommit]/[onAbort] run
+	// as in-memory side effects (e.g. updating VM fields) after the
+	// corresponding DB is committed.
+	SemanticVerify(db *versiondb.Database) (onCommitDB, onAbortDB *versiondb.Database, onCommit, onAbort func(), err error)
+
+	// InitiallyPrefersCommit returns whether the node proposing this tx
+	// prefers its CommitBlock over its AbortBlock, absent any other
+	// information. It seeds the engine's initial vote.
+	InitiallyPrefersCommit() bool
+}
+
+// SetMaxClockSkewTx proposes changing the maximum amount of clock skew
+// tolerated in StandardBlock.Verify/ProposalBlock.Verify from its current,
+// on-chain value to NewMaxClockSkew.
+type SetMaxClockSkewTx struct {
+	NewMaxClockSkew time.Duration `serialize:"true" json:"newMaxClockSkew"`
+
+	id    ids.ID
+	bytes []byte
+}
+
+// NewSetMaxClockSkewTx creates and initializes a SetMaxClockSkewTx.
+func NewSetMaxClockSkewTx(newMaxClockSkew time.Duration) (*SetMaxClockSkewTx, error) {
+	tx := &SetMaxClockSkewTx{NewMaxClockSkew: newMaxClockSkew}
+	bytes, err := Codec.Marshal(codecVersion, tx)
+	if err != nil {
+		return nil, err
+	}
+	tx.bytes = bytes
+	tx.id = hashing.ComputeHash256Array(bytes)
+	return tx, nil
+}
+
+func (tx *SetMaxClockSkewTx) ID() ids.ID    { return tx.id }
+func (tx *SetMaxClockSkewTx) Bytes() []byte { return tx.bytes }
+
+// SyntacticVerify returns nil iff the proposed skew is non-negative.
+func (tx *SetMaxClockSkewTx) SyntacticVerify() error {
+	if tx.NewMaxClockSkew < 0 {
+		return errNegativeClockSkew
+	}
+	return nil
+}
+
+// SemanticVerify stages [tx]'s change to the max clock skew parameter: on
+// commit it's written to [db], on abort [db] is left untouched.
+func (tx *SetMaxClockSkewTx) SemanticVerify(db *versiondb.Database) (*versiondb.Database, *versiondb.Database, func(), func(), error) {
+	onCommitDB := versiondb.New(db)
+	if err := NewState(onCommitDB).PutMaxClockSkew(tx.NewMaxClockSkew); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	onAbortDB := versiondb.New(db)
+
+	return onCommitDB, onAbortDB, func() {}, func() {}, nil
+}
+
+// InitiallyPrefersCommit always returns true: a node that issues this tx
+// wants its own proposed change to take effect.
+func (tx *SetMaxClockSkewTx) InitiallyPrefersCommit() bool { return true }
+
+// unmarshalProposalTx decodes [bytes] into the concrete ProposalTx type
+// they represent. SetMaxClockSkewTx is the only one the timestampvm
+// currently supports.
+func unmarshalProposalTx(bytes []byte) (ProposalTx, error) {
+	tx := &SetMaxClockSkewTx{}
+	if _, err := Codec.Unmarshal(bytes, tx); err != nil {
+		return nil, err
+	}
+	tx.bytes = bytes
+	tx.id = hashing.ComputeHash256Array(bytes)
+	return tx, nil
+}
@@ -0,0 +1,125 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"encoding/binary"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+)
+
+var lastAcceptedKey = []byte("last accepted")
+
+// heightKey returns the database key under which the ID of the accepted
+// block at [height] is stored.
+func heightKey(height uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = 'h'
+	binary.BigEndian.PutUint64(key[1:], height)
+	return key
+}
+
+// statusKey returns the database key under which the status a block was
+// last decided with is stored. A block's own serialized bytes are fixed at
+// construction time, before its status is known, so status can't be
+// recovered from them later; it's tracked in this separate record instead.
+func statusKey(blkID ids.ID) []byte {
+	key := make([]byte, 1+len(blkID))
+	key[0] = 's'
+	copy(key[1:], blkID[:])
+	return key
+}
+
+// State manages the persistence of timestampvm blocks.
+type State struct {
+	db *versiondb.Database
+}
+
+// NewState creates a new State that persists blocks to [db].
+func NewState(db *versiondb.Database) *State {
+	return &State{db: db}
+}
+
+// GetBlock returns the serialized bytes of the block with ID [blkID] from
+// persistent storage. Decoding them into a concrete Block and populating
+// its status/vm is VM.GetBlock's job.
+func (s *State) GetBlock(blkID ids.ID) ([]byte, error) {
+	return s.db.Get(blkID[:])
+}
+
+// PutBlock persists [blk] to storage along with the status it was decided
+// with. It does not touch the height index: a rejected block must never
+// become reachable via GetBlockIDAtHeight, so only PutAcceptedBlock indexes
+// by height. Callers persisting a rejected block must use this method, not
+// PutAcceptedBlock.
+func (s *State) PutBlock(blk Block) error {
+	blkID := blk.ID()
+	if err := s.db.Put(blkID[:], blk.Bytes()); err != nil {
+		return err
+	}
+	return s.putStatus(blkID, blk.Status())
+}
+
+// PutAcceptedBlock persists [blk] to storage along with the status it was
+// decided with, and indexes it by height, so it can later be fetched by
+// height in O(1) via GetBlockIDAtHeight. Only call this for a block that is
+// actually being accepted.
+func (s *State) PutAcceptedBlock(blk Block) error {
+	blkID := blk.ID()
+	if err := s.db.Put(blkID[:], blk.Bytes()); err != nil {
+		return err
+	}
+	if err := s.putStatus(blkID, blk.Status()); err != nil {
+		return err
+	}
+	return s.db.Put(heightKey(blk.Height()), blkID[:])
+}
+
+func (s *State) putStatus(blkID ids.ID, status choices.Status) error {
+	return s.db.Put(statusKey(blkID), []byte{byte(status)})
+}
+
+// GetBlockStatus returns the status a previously-decided block with ID
+// [blkID] was persisted with, so VM.GetBlock's database-fetch path can
+// restore a rejected block's true status instead of assuming Accepted.
+func (s *State) GetBlockStatus(blkID ids.ID) (choices.Status, error) {
+	bytes, err := s.db.Get(statusKey(blkID))
+	if err != nil {
+		return choices.Unknown, err
+	}
+	return choices.Status(bytes[0]), nil
+}
+
+// GetBlockIDAtHeight returns the ID of the accepted block at [height].
+func (s *State) GetBlockIDAtHeight(height uint64) (ids.ID, error) {
+	bytes, err := s.db.Get(heightKey(height))
+	if err != nil {
+		return ids.ID{}, err
+	}
+	return ids.ToID(bytes)
+}
+
+// GetLastAccepted returns the ID of the last accepted block.
+func (s *State) GetLastAccepted() (ids.ID, error) {
+	bytes, err := s.db.Get(lastAcceptedKey)
+	if err == database.ErrNotFound {
+		return ids.ID{}, database.ErrNotFound
+	} else if err != nil {
+		return ids.ID{}, err
+	}
+	return ids.ToID(bytes)
+}
+
+// SetLastAccepted records [blkID] as the ID of the last accepted block.
+func (s *State) SetLastAccepted(blkID ids.ID) error {
+	return s.db.Put(lastAcceptedKey, blkID[:])
+}
+
+// Commit persists all pending writes to the underlying database.
+func (s *State) Commit() error {
+	return s.db.Commit()
+}
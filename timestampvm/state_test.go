@@ -0,0 +1,61 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+)
+
+// TestStatePutBlockDoesNotIndexByHeight guards against a height index that
+// flips to a rejected block: it accepts one block at a height, rejects a
+// sibling at the same height (mirroring the engine, which doesn't guarantee
+// Accept/Reject ordering across competing blocks), and checks that the
+// height index still resolves to the accepted block.
+func TestStatePutBlockDoesNotIndexByHeight(t *testing.T) {
+	s := NewState(versiondb.New(memdb.New()))
+
+	const height = 1
+	accepted := newStandardBlock(ids.GenerateTestID(), height, nil, time.Unix(1, 0))
+	acceptedBytes, err := marshalBlock(blockKindStandard, accepted)
+	if err != nil {
+		t.Fatalf("marshalBlock(accepted): %v", err)
+	}
+	accepted.Initialize(acceptedBytes, choices.Accepted, nil)
+
+	rejected := newStandardBlock(ids.GenerateTestID(), height, nil, time.Unix(2, 0))
+	rejectedBytes, err := marshalBlock(blockKindStandard, rejected)
+	if err != nil {
+		t.Fatalf("marshalBlock(rejected): %v", err)
+	}
+	rejected.Initialize(rejectedBytes, choices.Rejected, nil)
+
+	if err := s.PutAcceptedBlock(accepted); err != nil {
+		t.Fatalf("PutAcceptedBlock: %v", err)
+	}
+	if err := s.PutBlock(rejected); err != nil {
+		t.Fatalf("PutBlock: %v", err)
+	}
+
+	gotID, err := s.GetBlockIDAtHeight(height)
+	if err != nil {
+		t.Fatalf("GetBlockIDAtHeight: %v", err)
+	}
+	if gotID != accepted.ID() {
+		t.Fatalf("height index resolved to %s, want accepted block %s", gotID, accepted.ID())
+	}
+
+	gotStatus, err := s.GetBlockStatus(rejected.ID())
+	if err != nil {
+		t.Fatalf("GetBlockStatus(rejected): %v", err)
+	}
+	if gotStatus != choices.Rejected {
+		t.Fatalf("rejected block's persisted status is %s, want %s", gotStatus, choices.Rejected)
+	}
+}
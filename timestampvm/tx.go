@@ -0,0 +1,68 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+)
+
+var _ Tx = &BaseTx{}
+
+// Tx is a transaction that can be submitted by a user and included in a
+// StandardBlock.
+type Tx interface {
+	// ID returns this tx's unique ID, the hash of its byte representation.
+	ID() ids.ID
+	// Bytes returns the byte representation of this tx.
+	Bytes() []byte
+	// SyntacticVerify returns nil iff this tx is well-formed, independent of
+	// any chain state. It's checked before the tx is admitted to the mempool.
+	SyntacticVerify() error
+	// SemanticVerify returns nil iff this tx may be applied on top of
+	// [state]. It's checked when the block containing this tx is verified,
+	// against the VM's current state (the last accepted block's post-state).
+	SemanticVerify(state *State) error
+}
+
+// BaseTx is the timestampvm's only Tx: an arbitrary, fixed-size blob of
+// user data.
+type BaseTx struct {
+	Dt [dataLen]byte `serialize:"true" json:"data"`
+
+	id    ids.ID
+	bytes []byte
+}
+
+// NewBaseTx creates and initializes a BaseTx carrying [data].
+func NewBaseTx(data [dataLen]byte) (*BaseTx, error) {
+	tx := &BaseTx{Dt: data}
+	bytes, err := Codec.Marshal(codecVersion, tx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal tx: %w", err)
+	}
+	tx.Initialize(bytes)
+	return tx, nil
+}
+
+// Initialize sets [tx.bytes] to [bytes] and [tx.id] to hash([bytes]).
+func (tx *BaseTx) Initialize(bytes []byte) {
+	tx.bytes = bytes
+	tx.id = hashing.ComputeHash256Array(bytes)
+}
+
+// ID returns the ID of this tx.
+func (tx *BaseTx) ID() ids.ID { return tx.id }
+
+// Bytes returns the byte repr. of this tx.
+func (tx *BaseTx) Bytes() []byte { return tx.bytes }
+
+// SyntacticVerify returns nil; every [dataLen]byte blob is well-formed.
+func (tx *BaseTx) SyntacticVerify() error { return nil }
+
+// SemanticVerify returns nil; a BaseTx doesn't depend on chain state beyond
+// being included in a block.
+func (tx *BaseTx) SemanticVerify(*State) error { return nil }
@@ -0,0 +1,380 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package timestampvm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/manager"
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/snow/consensus/snowman"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/utils/cache"
+
+	"github.com/ava-labs/timestampvm/log"
+)
+
+const (
+	dataLen = 32
+
+	// maxTxsPerBlock bounds how many pending txs BuildBlock pulls from the
+	// mempool at once.
+	maxTxsPerBlock = 64
+)
+
+var (
+	errNoPendingTxs     = errors.New("there are currently no pending txs, cannot build a block")
+	errEmptyBlockBytes  = errors.New("can't parse a block from zero bytes")
+	errUnknownBlockKind = errors.New("unknown block kind")
+)
+
+// pendingProposal holds the two divergent database states a verified
+// ProposalBlock's tx produced, waiting to find out via its CommitBlock or
+// AbortBlock child which one consensus actually wants.
+type pendingProposal struct {
+	onCommitDB, onAbortDB *versiondb.Database
+	onCommit, onAbort     func()
+}
+
+// VM implements the snowman.ChainVM interface for the timestamp chain.
+type VM struct {
+	ctx *snow.Context
+
+	state *State
+
+	// mempool holds txs that have been issued locally or gossiped in by
+	// other nodes, but that have not yet been accepted into a block.
+	mempool Mempool
+
+	// pendingProposalTx, if set, is a ProposalTx waiting for BuildBlock to
+	// wrap it in a ProposalBlock.
+	pendingProposalTx ProposalTx
+
+	// pendingProposals holds the staged onCommit/onAbort state for every
+	// verified ProposalBlock that hasn't yet been decided by a CommitBlock
+	// or AbortBlock, keyed by the ProposalBlock's ID.
+	pendingProposals map[ids.ID]*pendingProposal
+
+	// verifiedBlocks holds blocks that have been verified but are not yet
+	// accepted or rejected, keyed by ID.
+	verifiedBlocks map[ids.ID]Block
+
+	// blockCache holds recently accepted blocks, keyed by ID,
+	// with their choices.Status already populated so GetBlock can serve
+	// them without a DB round trip.
+	blockCache cache.Cacher
+	// missingCache negatively caches IDs that aren't in the database, so
+	// repeated lookups for an unknown block (e.g. during bootstrapping)
+	// don't keep hitting the DB.
+	missingCache cache.Cacher
+
+	// log is this VM instance's Logger. A process hosts one VM instance
+	// per chain using this VM ID, so this must stay an instance field, not
+	// package-level state shared across instances.
+	log log.Logger
+
+	preferred ids.ID
+}
+
+// Initialize sets up the VM's persistent state and mempool.
+func (vm *VM) Initialize(
+	ctx *snow.Context,
+	dbManager manager.Manager,
+	genesisBytes []byte,
+	upgradeBytes []byte,
+	configBytes []byte,
+	toEngine chan<- common.Message,
+	fxs []*common.Fx,
+	appSender common.AppSender,
+) error {
+	config := Config{BlockCacheSize: defaultBlockCacheSize}
+	if len(configBytes) > 0 {
+		if err := json.Unmarshal(configBytes, &config); err != nil {
+			return err
+		}
+	}
+
+	vm.ctx = ctx
+	vm.log = log.NewCtxLogger(ctx.Log)
+	vm.mempool = NewMempool()
+	vm.pendingProposals = make(map[ids.ID]*pendingProposal)
+	vm.verifiedBlocks = make(map[ids.ID]Block)
+	vm.blockCache = &cache.LRU{Size: config.BlockCacheSize}
+	vm.missingCache = &cache.LRU{Size: config.BlockCacheSize}
+	vm.state = NewState(versiondb.New(dbManager.Current().Database))
+
+	lastAccepted, err := vm.state.GetLastAccepted()
+	if err != nil {
+		return vm.initGenesis(genesisBytes)
+	}
+
+	vm.preferred = lastAccepted
+	return nil
+}
+
+// SetLogger replaces the Logger that Initialize installed with [l], e.g.
+// one backed by zap or logrus. It only affects this VM instance.
+func (vm *VM) SetLogger(l log.Logger) {
+	vm.log = l
+}
+
+// initGenesis builds the genesis block, a StandardBlock with no txs at
+// height 0 and time 0, and accepts it. [genesisBytes] is not used.
+func (vm *VM) initGenesis(genesisBytes []byte) error {
+	genesisBlock := newStandardBlock(ids.Empty, 0, nil, time.Unix(0, 0))
+	bytes, err := marshalBlock(blockKindStandard, genesisBlock)
+	if err != nil {
+		return err
+	}
+	genesisBlock.Initialize(bytes, choices.Accepted, vm)
+
+	if err := vm.state.PutAcceptedBlock(genesisBlock); err != nil {
+		return err
+	}
+	if err := vm.state.SetLastAccepted(genesisBlock.ID()); err != nil {
+		return err
+	}
+	vm.preferred = genesisBlock.ID()
+	return vm.state.Commit()
+}
+
+// IssueProposalTx queues [tx] to be wrapped in a ProposalBlock the next
+// time BuildBlock is called.
+func (vm *VM) IssueProposalTx(tx ProposalTx) error {
+	if err := tx.SyntacticVerify(); err != nil {
+		return err
+	}
+	vm.pendingProposalTx = tx
+	return nil
+}
+
+// BuildBlock proposes the next block to add to the chain. If the preferred
+// block is an undecided ProposalBlock, it builds that proposal's
+// CommitBlock or AbortBlock child instead of a new StandardBlock; if a
+// ProposalTx is queued, it wraps that tx in a new ProposalBlock; otherwise
+// it pulls pending txs off the mempool into a StandardBlock.
+func (vm *VM) BuildBlock() (snowman.Block, error) {
+	preferred, err := vm.GetBlock(vm.preferred)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal, ok := preferred.(*ProposalBlock); ok {
+		return vm.buildDecisionBlock(proposal)
+	}
+
+	if vm.pendingProposalTx != nil {
+		tx := vm.pendingProposalTx
+		vm.pendingProposalTx = nil
+		return vm.buildProposalBlock(preferred, tx)
+	}
+
+	return vm.buildStandardBlock(preferred)
+}
+
+// buildDecisionBlock builds the CommitBlock or AbortBlock that decides
+// [proposal], preferring a commit iff the underlying tx does.
+func (vm *VM) buildDecisionBlock(proposal *ProposalBlock) (snowman.Block, error) {
+	height := proposal.Height() + 1
+	var (
+		blk  Block
+		kind blockKind
+	)
+	if proposal.tx.InitiallyPrefersCommit() {
+		blk, kind = newCommitBlock(proposal.ID(), height), blockKindCommit
+	} else {
+		blk, kind = newAbortBlock(proposal.ID(), height), blockKindAbort
+	}
+
+	bytes, err := marshalBlock(kind, blk)
+	if err != nil {
+		return nil, err
+	}
+	blk.Initialize(bytes, choices.Processing, vm)
+
+	if err := blk.Verify(); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// buildProposalBlock wraps [tx] in a new ProposalBlock extending
+// [preferred].
+func (vm *VM) buildProposalBlock(preferred Block, tx ProposalTx) (snowman.Block, error) {
+	blk := newProposalBlock(preferred.ID(), preferred.Height()+1, tx, time.Now())
+	bytes, err := marshalBlock(blockKindProposal, blk)
+	if err != nil {
+		return nil, err
+	}
+	blk.Initialize(bytes, choices.Processing, vm)
+
+	if err := blk.Verify(); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// buildStandardBlock pulls pending txs off the mempool and proposes a new
+// StandardBlock containing them.
+func (vm *VM) buildStandardBlock(preferred Block) (snowman.Block, error) {
+	pending := vm.mempool.Peek(maxTxsPerBlock)
+	if len(pending) == 0 {
+		return nil, errNoPendingTxs
+	}
+	txs := make([]*BaseTx, len(pending))
+	for i, tx := range pending {
+		txs[i] = tx.(*BaseTx)
+	}
+
+	blk := newStandardBlock(preferred.ID(), preferred.Height()+1, txs, time.Now())
+	bytes, err := marshalBlock(blockKindStandard, blk)
+	if err != nil {
+		return nil, err
+	}
+	blk.Initialize(bytes, choices.Processing, vm)
+
+	if err := blk.Verify(); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// marshalBlock serializes [blk] and prepends the byte tagging which
+// concrete block type it is, so parseBlockBytes can dispatch on it later.
+func marshalBlock(kind blockKind, blk interface{}) ([]byte, error) {
+	body, err := Codec.Marshal(codecVersion, blk)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(kind)}, body...), nil
+}
+
+// parseBlockBytes decodes [bytes] into the concrete block type tagged by
+// its first byte. It doesn't set the block's status or vm; the caller must
+// call Initialize.
+func parseBlockBytes(bytes []byte) (Block, error) {
+	if len(bytes) == 0 {
+		return nil, errEmptyBlockBytes
+	}
+	body := bytes[1:]
+
+	switch blockKind(bytes[0]) {
+	case blockKindStandard:
+		blk := &StandardBlock{}
+		if _, err := Codec.Unmarshal(body, blk); err != nil {
+			return nil, err
+		}
+		return blk, nil
+	case blockKindProposal:
+		blk := &ProposalBlock{}
+		if _, err := Codec.Unmarshal(body, blk); err != nil {
+			return nil, err
+		}
+		tx, err := unmarshalProposalTx(blk.TxBytes)
+		if err != nil {
+			return nil, err
+		}
+		blk.tx = tx
+		return blk, nil
+	case blockKindCommit:
+		blk := &CommitBlock{}
+		if _, err := Codec.Unmarshal(body, blk); err != nil {
+			return nil, err
+		}
+		return blk, nil
+	case blockKindAbort:
+		blk := &AbortBlock{}
+		if _, err := Codec.Unmarshal(body, blk); err != nil {
+			return nil, err
+		}
+		return blk, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnknownBlockKind, bytes[0])
+	}
+}
+
+// ParseBlock deserializes [bytes] into a block.
+func (vm *VM) ParseBlock(bytes []byte) (snowman.Block, error) {
+	blk, err := parseBlockBytes(bytes)
+	if err != nil {
+		return nil, err
+	}
+	blk.Initialize(bytes, choices.Processing, vm)
+	return blk, nil
+}
+
+// GetBlock returns the block with ID [blkID]. Accepted blocks are served
+// out of vm.blockCache, avoiding a DB round trip; a prior miss
+// is remembered in vm.missingCache so repeated lookups for an unknown ID
+// don't keep hitting the DB either.
+func (vm *VM) GetBlock(blkID ids.ID) (snowman.Block, error) {
+	if blk, ok := vm.verifiedBlocks[blkID]; ok {
+		return blk, nil
+	}
+	if blkIntf, ok := vm.blockCache.Get(blkID); ok {
+		blk, _ := blkIntf.(Block)
+		return blk, nil
+	}
+	if _, ok := vm.missingCache.Get(blkID); ok {
+		return nil, database.ErrNotFound
+	}
+
+	bytes, err := vm.state.GetBlock(blkID)
+	if err == database.ErrNotFound {
+		vm.missingCache.Put(blkID, struct{}{})
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	status, err := vm.state.GetBlockStatus(blkID)
+	if err != nil {
+		return nil, err
+	}
+
+	blk, err := parseBlockBytes(bytes)
+	if err != nil {
+		return nil, err
+	}
+	blk.Initialize(bytes, status, vm)
+
+	// Only accepted blocks are cached; a rejected block is read from the
+	// database on every lookup.
+	if status == choices.Accepted {
+		vm.blockCache.Put(blkID, blk)
+	}
+	return blk, nil
+}
+
+// getBlockByHeight returns the accepted block at [height], using the
+// height index maintained by State. This backs a future getBlockByHeight
+// API endpoint.
+func (vm *VM) getBlockByHeight(height uint64) (Block, error) {
+	blkID, err := vm.state.GetBlockIDAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	blk, err := vm.GetBlock(blkID)
+	if err != nil {
+		return nil, err
+	}
+	return blk.(Block), nil
+}
+
+// SetPreference sets the block with ID [blkID] as the preferred block.
+func (vm *VM) SetPreference(blkID ids.ID) {
+	vm.preferred = blkID
+}
+
+// LastAccepted returns the ID of the last accepted block.
+func (vm *VM) LastAccepted() (ids.ID, error) {
+	return vm.state.GetLastAccepted()
+}